@@ -0,0 +1,137 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/xanzy/go-gitlab"
+	"golang.org/x/oauth2"
+)
+
+// newClient builds the go-gitlab client according to h.AuthMethod. The
+// "pat" method (the default) behaves exactly as before: a bare personal
+// access token. The "oauth2" method wraps the HTTP client so expired access
+// tokens are refreshed transparently, and persists the refreshed token to
+// TokenCachePath when set.
+func (h *Gitlab) newClient(acc telegraf.Accumulator) (*gitlab.Client, error) {
+	switch h.AuthMethod {
+	case "", "pat":
+		return gitlab.NewClient(&http.Client{Transport: h.newTransport(http.DefaultTransport, acc)}, h.Token)
+	case "oauth2":
+		return h.newOAuth2Client(acc)
+	default:
+		return nil, fmt.Errorf("unknown auth_method %q", h.AuthMethod)
+	}
+}
+
+func (h *Gitlab) newOAuth2Client(acc telegraf.Accumulator) (*gitlab.Client, error) {
+	if h.OAuth2ClientID == "" || h.OAuth2ClientSecret == "" || h.OAuth2RefreshToken == "" || h.OAuth2TokenURL == "" {
+		return nil, fmt.Errorf("oauth2 auth_method requires oauth2_client_id, oauth2_client_secret, oauth2_refresh_token and oauth2_token_url")
+	}
+
+	conf := &oauth2.Config{
+		ClientID:     h.OAuth2ClientID,
+		ClientSecret: h.OAuth2ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			TokenURL: h.OAuth2TokenURL,
+		},
+	}
+
+	token := &oauth2.Token{RefreshToken: h.OAuth2RefreshToken}
+	if cached, err := readCachedToken(h.TokenCachePath); err != nil {
+		return nil, fmt.Errorf("unable to read token cache %s: %w", h.TokenCachePath, err)
+	} else if cached != nil {
+		token = cached
+	}
+
+	tokenSource := &cachingTokenSource{
+		wrapped:   conf.TokenSource(h.ctx, token),
+		cachePath: h.TokenCachePath,
+	}
+
+	ctx := context.WithValue(h.ctx, oauth2.HTTPClient, &http.Client{Transport: h.newTransport(http.DefaultTransport, acc)})
+	httpClient := oauth2.NewClient(ctx, tokenSource)
+
+	// Pass no static token: the oauth2 transport above is solely responsible
+	// for the Authorization header, refreshed as needed. Using
+	// NewOAuthClient here would make go-gitlab set its own Authorization
+	// header from a fixed token, racing with the oauth2 transport's header.
+	return gitlab.NewClient(httpClient, "")
+}
+
+// readCachedToken loads a previously persisted OAuth2 token from path, if
+// any, so a restart can resume without re-authenticating against
+// OAuth2TokenURL from the bare configured refresh token. A missing file is
+// not an error: it just means there's nothing cached yet.
+func readCachedToken(path string) (*oauth2.Token, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// cachingTokenSource wraps an oauth2.TokenSource and persists every newly
+// issued token to cachePath so a restart doesn't need to re-authenticate.
+type cachingTokenSource struct {
+	wrapped   oauth2.TokenSource
+	cachePath string
+}
+
+func (c *cachingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := c.wrapped.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cachePath != "" {
+		data, marshalErr := json.Marshal(token)
+		if marshalErr != nil {
+			return token, fmt.Errorf("token refreshed but unable to encode for %s: %w", c.cachePath, marshalErr)
+		}
+		if writeErr := ioutil.WriteFile(c.cachePath, data, 0600); writeErr != nil {
+			return token, fmt.Errorf("token refreshed but unable to persist to %s: %w", c.cachePath, writeErr)
+		}
+	}
+
+	return token, nil
+}
+
+// isTerminalAuthError reports whether err represents a GitLab 401 response
+// for an invalid or revoked token. These are not worth retrying: the agent
+// should surface the error and stop polling rather than hammering the
+// endpoint with a token that will never work again.
+func isTerminalAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var resp *http.Response
+	if errResp, ok := err.(*gitlab.ErrorResponse); ok {
+		resp = errResp.Response
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "invalid_token") || strings.Contains(msg, "revoked_token")
+}
@@ -0,0 +1,45 @@
+package gitlab
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func newErrorResponse(statusCode int, message string) *gitlab.ErrorResponse {
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Scheme: "https", Host: "gitlab.example.com", Path: "/api/v4/projects"},
+	}
+	return &gitlab.ErrorResponse{
+		Response: &http.Response{StatusCode: statusCode, Request: req},
+		Message:  message,
+	}
+}
+
+func TestIsTerminalAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil error", nil, false},
+		{"not an ErrorResponse", errors.New("some other error"), false},
+		{"401 invalid_token", newErrorResponse(http.StatusUnauthorized, "invalid_token"), true},
+		{"401 revoked_token", newErrorResponse(http.StatusUnauthorized, "revoked_token"), true},
+		{"401 unrecognized message", newErrorResponse(http.StatusUnauthorized, "something else"), false},
+		{"403 forbidden", newErrorResponse(http.StatusForbidden, "invalid_token"), false},
+		{"500 server error", newErrorResponse(http.StatusInternalServerError, "invalid_token"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTerminalAuthError(tt.err); got != tt.want {
+				t.Errorf("isTerminalAuthError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package gitlab
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// repoNames returns the project names the per-repo collectors should
+// iterate: the configured Repos allowlist when set, or every discovered
+// project otherwise.
+func (h *Gitlab) repoNames() []string {
+	if len(h.Repos) > 0 {
+		return h.Repos
+	}
+
+	names := make([]string, 0, len(h.projects))
+	for _, name := range h.projects {
+		names = append(names, name)
+	}
+	return names
+}
+
+// discoverProjects walks h.Groups (recursing into subgroups when
+// IncludeSubgroups is set) and returns every project found, after applying
+// ProjectNameRegex, ExcludeArchived and MembershipOnly filters. This is used
+// instead of Projects.ListProjects, whose first page silently caps at 20
+// results on large self-hosted GitLabs.
+func (h *Gitlab) discoverProjects() (map[int]string, error) {
+	var nameFilter *regexp.Regexp
+	if h.ProjectNameRegex != "" {
+		re, err := regexp.Compile(h.ProjectNameRegex)
+		if err != nil {
+			return nil, err
+		}
+		nameFilter = re
+	}
+
+	var membership map[int]bool
+	if h.MembershipOnly {
+		var err error
+		membership, err = h.membershipProjectIDs()
+		if err != nil {
+			return nil, fmt.Errorf("unable to list membership projects: %w", err)
+		}
+	}
+
+	projects := make(map[int]string)
+	for _, group := range h.Groups {
+		page := 0
+		pp := 100
+		for {
+			opts := &gitlab.ListGroupProjectsOptions{
+				ListOptions:      gitlab.ListOptions{PerPage: pp, Page: page},
+				IncludeSubGroups: gitlab.Bool(h.IncludeSubgroups),
+			}
+			if h.ExcludeArchived {
+				opts.Archived = gitlab.Bool(false)
+			}
+
+			ps, resp, err := h.client.Groups.ListGroupProjects(group, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range ps {
+				if nameFilter != nil && !nameFilter.MatchString(p.Name) {
+					continue
+				}
+				if membership != nil && !membership[p.ID] {
+					continue
+				}
+				projects[p.ID] = p.Name
+			}
+
+			resp.Body.Close()
+			page++
+			if len(ps) < pp {
+				break
+			}
+		}
+	}
+
+	return projects, nil
+}
+
+// membershipProjectIDs returns the IDs of every project the authenticating
+// token is a member of. GitLab's group-projects endpoint has no membership
+// filter of its own, so MembershipOnly is implemented by intersecting
+// discovered projects against this set from the top-level projects endpoint.
+func (h *Gitlab) membershipProjectIDs() (map[int]bool, error) {
+	ids := make(map[int]bool)
+	page := 0
+	pp := 100
+	for {
+		ps, resp, err := h.client.Projects.ListProjects(&gitlab.ListProjectsOptions{
+			ListOptions: gitlab.ListOptions{PerPage: pp, Page: page},
+			Membership:  gitlab.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range ps {
+			ids[p.ID] = true
+		}
+
+		resp.Body.Close()
+		page++
+		if len(ps) < pp {
+			break
+		}
+	}
+
+	return ids, nil
+}
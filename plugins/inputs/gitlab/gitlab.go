@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -28,6 +29,82 @@ const (
   ## Repos:
   ## - List of projects to pull from
   Repos = ["abc", "def", "ghi"]
+
+  ## CollectPipelines:
+  ## - Set to true to emit gitlab_pipelines measurements for each Repo
+  # CollectPipelines = false
+  ## CollectJobs:
+  ## - Set to true to emit gitlab_jobs measurements for each Repo
+  # CollectJobs = false
+  ## PipelineStatuses:
+  ## - Only collect pipelines in these statuses. Empty means all statuses.
+  # PipelineStatuses = ["failed", "success"]
+  ## SincePipelines:
+  ## - Only emit pipelines created after now minus this duration.
+  ## - Older pipelines are still listed and fetched, then skipped.
+  # SincePipelines = "24h"
+
+  ## ListenAddress:
+  ## - When set, Telegraf starts an HTTP server on this address to receive
+  ##   GitLab webhook events in addition to polling.
+  ## - WebhookSecret is required whenever this is set; Start fails closed
+  ##   rather than run an unauthenticated receiver.
+  # ListenAddress = ":1619"
+  ## WebhookSecret:
+  ## - Must match the "Secret token" configured on the GitLab webhook, and is
+  ##   checked against the X-Gitlab-Token header on every request.
+  # WebhookSecret = "abcd1234"
+
+  ## AuthMethod:
+  ## - "pat" (default) uses the Token field as a personal access token.
+  ## - "oauth2" uses the OAuth2* fields below and refreshes automatically.
+  # AuthMethod = "pat"
+  ## OAuth2ClientID, OAuth2ClientSecret, OAuth2RefreshToken, OAuth2TokenURL:
+  ## - Required when AuthMethod = "oauth2".
+  # OAuth2ClientID = ""
+  # OAuth2ClientSecret = ""
+  # OAuth2RefreshToken = ""
+  # OAuth2TokenURL = "https://gitlab.com/oauth/token"
+  ## TokenCachePath:
+  ## - Optional path to persist the refreshed OAuth2 token so restarts don't
+  ##   have to re-authenticate.
+  # TokenCachePath = ""
+
+  ## Groups:
+  ## - Discover projects by group/namespace path instead of hand-maintaining
+  ##   Repos. When set, Repos (if also set) is applied as an allowlist
+  ##   intersection against the discovered projects.
+  # Groups = ["my-org", "my-org/subteam"]
+  ## IncludeSubgroups:
+  ## - Recurse into subgroups of each entry in Groups.
+  # IncludeSubgroups = false
+  ## ProjectNameRegex:
+  ## - Only keep discovered projects whose name matches this regex.
+  # ProjectNameRegex = ""
+  ## ExcludeArchived:
+  ## - Drop archived projects from discovery.
+  # ExcludeArchived = false
+  ## MembershipOnly:
+  ## - Only discover projects the configured token is a member of.
+  # MembershipOnly = false
+
+  ## MaxRetries:
+  ## - Number of times to retry a request that came back 429 or 5xx, with
+  ##   exponential backoff and jitter between attempts.
+  # MaxRetries = 5
+  ## MaxConcurrentRequests:
+  ## - Upper bound on in-flight requests to the GitLab API at any one time.
+  # MaxConcurrentRequests = 10
+  ## RateLimitFloor:
+  ## - When the RateLimit-Remaining response header drops to this value,
+  ##   sleep until RateLimit-Reset before issuing further requests.
+  # RateLimitFloor = 5
+
+  ## CollectMREvents:
+  ## - Set to true to additionally fetch state/label events and notes for
+  ##   every merge request, to support review-latency style metrics. This
+  ##   costs three extra API calls per merge request, so it's opt-in.
+  # CollectMREvents = false
  `
 )
 
@@ -38,9 +115,38 @@ type Gitlab struct {
 	cancel      context.CancelFunc
 	currentPage int
 	projects    map[int]string
+	projectIDs  map[string]int
+	server      *http.Server
 	Token       string
 	Endpoint    string
 	Repos       []string
+
+	Groups           []string
+	IncludeSubgroups bool
+	ProjectNameRegex string
+	ExcludeArchived  bool
+	MembershipOnly   bool
+
+	MaxRetries            int
+	MaxConcurrentRequests int
+	RateLimitFloor        int
+
+	CollectMREvents bool
+
+	CollectPipelines bool
+	CollectJobs      bool
+	PipelineStatuses []string
+	SincePipelines   time.Duration
+
+	ListenAddress string
+	WebhookSecret string
+
+	AuthMethod         string
+	OAuth2ClientID     string
+	OAuth2ClientSecret string
+	OAuth2RefreshToken string
+	OAuth2TokenURL     string
+	TokenCachePath     string
 }
 
 // Description - display description
@@ -56,6 +162,9 @@ func (h *Gitlab) Gather(acc telegraf.Accumulator) error {
 
 func (h *Gitlab) Stop() {
 	h.cancel()
+	if h.server != nil {
+		h.server.Close()
+	}
 	h.wg.Wait()
 }
 
@@ -69,38 +178,85 @@ func (h *Gitlab) Start(acc telegraf.Accumulator) error {
 		return fmt.Errorf("invalid URL \"%s\"", h.Endpoint)
 	}
 
-	h.client = gitlab.NewClient(nil, h.Token)
+	h.client, err = h.newClient(acc)
+	if err != nil {
+		return fmt.Errorf("unable to build gitlab client: %w", err)
+	}
 	h.client.SetBaseURL(h.Endpoint)
 	//visibility := gitlab.InternalVisibility
 
-	ps, resp, err := h.client.Projects.ListProjects(&gitlab.ListProjectsOptions{
-		//Visibility: &visibility,
-	})
-	if err != nil {
-		return fmt.Errorf("unable to perform HTTP client GET on \"%s\": %s", h.Endpoint, err)
+	if len(h.Groups) > 0 {
+		h.projects, err = h.discoverProjects()
+		if err != nil {
+			return fmt.Errorf("unable to discover projects: %w", err)
+		}
+	} else {
+		ps, resp, err := h.client.Projects.ListProjects(&gitlab.ListProjectsOptions{
+			//Visibility: &visibility,
+		})
+		if err != nil {
+			if isTerminalAuthError(err) {
+				return fmt.Errorf("gitlab token is invalid or revoked: %w", err)
+			}
+			return fmt.Errorf("unable to perform HTTP client GET on \"%s\": %s", h.Endpoint, err)
+		}
+
+		h.projects = make(map[int]string)
+		for _, p := range ps {
+			h.projects[p.ID] = p.Name
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("http status ok not met")
+		}
 	}
 
-	h.projects = make(map[int]string)
-	for _, p := range ps {
-		h.projects[p.ID] = p.Name
+	if len(h.Repos) > 0 && len(h.Groups) > 0 {
+		allow := make(map[string]bool, len(h.Repos))
+		for _, rep := range h.Repos {
+			allow[rep] = true
+		}
+		for id, name := range h.projects {
+			if !allow[name] {
+				delete(h.projects, id)
+			}
+		}
 	}
-	resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http status ok not met")
+
+	h.projectIDs = make(map[string]int, len(h.projects))
+	for id, name := range h.projects {
+		h.projectIDs[name] = id
 	}
 
 	go h.fetchMergeRequests(acc)
 	go h.fetchCommits(acc)
+	if h.CollectPipelines {
+		h.wg.Add(1)
+		go h.fetchPipelines(acc)
+	}
+	if h.CollectJobs {
+		h.wg.Add(1)
+		go h.fetchJobs(acc)
+	}
+
+	if h.ListenAddress != "" {
+		if h.WebhookSecret == "" {
+			return fmt.Errorf("listen_address is set but webhook_secret is empty: refusing to start an unauthenticated webhook receiver")
+		}
+		h.wg.Add(1)
+		go h.listen(acc)
+	}
 	return nil
 }
 
 func (h *Gitlab) fetchCommits(acc telegraf.Accumulator) {
 
-	for _, rep := range h.Repos {
+	for _, rep := range h.repoNames() {
 
-		key, ok := mapkey(h.projects, rep)
+		key, ok := h.projectIDs[rep]
 		if !ok {
 			acc.AddError(fmt.Errorf("value does not exist in map"))
+			continue
 		}
 
 		pp := 100
@@ -110,7 +266,13 @@ func (h *Gitlab) fetchCommits(acc telegraf.Accumulator) {
 				ListOptions: gitlab.ListOptions{PerPage: pp, Page: page},
 			})
 			if err != nil {
-				acc.AddError(fmt.Errorf("unable to list project : ", rep, err))
+				if isTerminalAuthError(err) {
+					acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+					h.cancel()
+					return
+				}
+				acc.AddError(fmt.Errorf("unable to list commits for %s: %w", rep, err))
+				break
 			}
 			for _, commit := range commits {
 
@@ -152,6 +314,11 @@ func (h *Gitlab) fetchMergeRequests(acc telegraf.Accumulator) {
 		})
 
 		if err != nil {
+			if isTerminalAuthError(err) {
+				acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+				h.cancel()
+				return
+			}
 			acc.AddError(fmt.Errorf("unable to list merge requests, %+v", err))
 			break
 		}
@@ -183,6 +350,10 @@ func (h *Gitlab) fetchMergeRequests(acc telegraf.Accumulator) {
 				"merge_request_type": mrType,
 			}
 			acc.AddFields("merge_requests", tmpFields, tmpTags, *mr.CreatedAt)
+
+			if h.CollectMREvents && h.fetchMergeRequestEvents(acc, mr) {
+				return
+			}
 		}
 		rs.Body.Close()
 		page += 1
@@ -218,17 +389,6 @@ func getMRType(s string) string {
 	return tempData[0]
 }
 
-func mapkey(m map[int]string, value string) (key int, ok bool) {
-	for k, v := range m {
-		if v == value {
-			key = k
-			ok = true
-			return
-		}
-	}
-	return
-}
-
 func init() {
 	inputs.Add("gitlab", func() telegraf.Input { return &Gitlab{} })
 }
@@ -0,0 +1,143 @@
+package gitlab
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/xanzy/go-gitlab"
+)
+
+// fetchMergeRequestEvents collects the review timeline for a single merge
+// request: resource state changes, label changes and notes. It unlocks
+// derived metrics like review latency, time-in-review and time-to-first-
+// comment, none of which are computable from the single merge_requests row
+// emitted by fetchMergeRequests. It returns true if a terminal auth error
+// was hit, so the caller can stop iterating further MRs.
+func (h *Gitlab) fetchMergeRequestEvents(acc telegraf.Accumulator, mr *gitlab.MergeRequest) (terminal bool) {
+	project := h.projects[mr.ProjectID]
+
+	stateEvents, rs, err := h.client.ResourceStateEvents.ListStateEvents(mr.ProjectID, mr.IID, &gitlab.ListStateEventsOptions{})
+	if err != nil {
+		if isTerminalAuthError(err) {
+			acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+			h.cancel()
+			return true
+		}
+		acc.AddError(fmt.Errorf("unable to list state events for MR !%d in %s: %w", mr.IID, project, err))
+	} else {
+		rs.Body.Close()
+		for _, event := range stateEvents {
+			tmpFields := map[string]interface{}{
+				"mr_iid": mr.IID,
+			}
+			tmpTags := map[string]string{
+				"project": project,
+				"actor":   event.User.Username,
+				"action":  event.State,
+			}
+			acc.AddFields("gitlab_mr_state_events", tmpFields, tmpTags, *event.CreatedAt)
+		}
+	}
+
+	labelEvents, rs, err := h.client.ResourceLabelEvents.ListMergeLabelEvents(mr.ProjectID, mr.IID, &gitlab.ListLabelEventsOptions{})
+	if err != nil {
+		if isTerminalAuthError(err) {
+			acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+			h.cancel()
+			return true
+		}
+		acc.AddError(fmt.Errorf("unable to list label events for MR !%d in %s: %w", mr.IID, project, err))
+	} else {
+		rs.Body.Close()
+		for _, event := range labelEvents {
+			tmpFields := map[string]interface{}{
+				"mr_iid": mr.IID,
+			}
+			tmpTags := map[string]string{
+				"project": project,
+				"actor":   event.User.Username,
+				"action":  classifyLabelEvent(event.Action),
+			}
+			acc.AddFields("gitlab_mr_label_events", tmpFields, tmpTags, *event.CreatedAt)
+		}
+	}
+
+	notes, rs, err := h.client.Notes.ListMergeRequestNotes(mr.ProjectID, mr.IID, &gitlab.ListMergeRequestNotesOptions{})
+	if err != nil {
+		if isTerminalAuthError(err) {
+			acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+			h.cancel()
+			return true
+		}
+		acc.AddError(fmt.Errorf("unable to list notes for MR !%d in %s: %w", mr.IID, project, err))
+	} else {
+		rs.Body.Close()
+		for _, note := range notes {
+			action := "commented"
+			if note.System {
+				action = classifySystemNote(note.Body)
+			}
+
+			tmpFields := map[string]interface{}{
+				"mr_iid": mr.IID,
+				"note":   note.Body,
+			}
+			tmpTags := map[string]string{
+				"project": project,
+				"actor":   note.Author.Username,
+				"action":  action,
+			}
+			acc.AddFields("gitlab_mr_notes", tmpFields, tmpTags, *note.CreatedAt)
+		}
+	}
+
+	return false
+}
+
+// classifySystemNote maps a GitLab system note body to one of a small fixed
+// set of action tags (closed/reopened/merged/approved/unapproved/labeled/
+// unlabeled/marked_draft/marked_ready), so "action" stays a low-cardinality tag instead of
+// forwarding arbitrary note text. Bodies that don't match a known phrasing
+// fall back to "system" rather than the free-text body.
+func classifySystemNote(body string) string {
+	b := strings.ToLower(body)
+
+	switch {
+	case strings.Contains(b, "unapproved"):
+		return "unapproved"
+	case strings.Contains(b, "approved"):
+		return "approved"
+	case strings.Contains(b, "reopened"):
+		return "reopened"
+	case strings.Contains(b, "closed"):
+		return "closed"
+	case strings.Contains(b, "merged"):
+		return "merged"
+	case strings.Contains(b, "removed") && strings.Contains(b, "label"):
+		return "unlabeled"
+	case strings.Contains(b, "added") && strings.Contains(b, "label"):
+		return "labeled"
+	case strings.Contains(b, "marked this merge request as **draft**"), strings.Contains(b, "marked as a work in progress"):
+		return "marked_draft"
+	case strings.Contains(b, "marked this merge request as ready"):
+		return "marked_ready"
+	default:
+		return "system"
+	}
+}
+
+// classifyLabelEvent maps go-gitlab's raw ResourceLabelEvent action
+// ("add"/"remove") onto the same labeled/unlabeled vocabulary
+// classifySystemNote uses for the equivalent system note, so the two
+// measurements agree on what a label change is called.
+func classifyLabelEvent(action gitlab.LabelEventAction) string {
+	switch action {
+	case gitlab.AddLabelEventAction:
+		return "labeled"
+	case gitlab.RemoveLabelEventAction:
+		return "unlabeled"
+	default:
+		return string(action)
+	}
+}
@@ -0,0 +1,55 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestClassifySystemNote(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"approved", "approved this merge request", "approved"},
+		{"unapproved", "unapproved this merge request", "unapproved"},
+		{"reopened", "reopened this merge request", "reopened"},
+		{"closed", "closed this merge request", "closed"},
+		{"merged", "merged this merge request", "merged"},
+		{"added label", "added ~bug label", "labeled"},
+		{"removed label", "removed ~bug label", "unlabeled"},
+		{"marked draft", "marked this merge request as **draft**", "marked_draft"},
+		{"marked wip", "marked as a work in progress", "marked_draft"},
+		{"marked ready", "marked this merge request as ready", "marked_ready"},
+		{"unrecognized", "changed the description", "system"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifySystemNote(tt.body); got != tt.want {
+				t.Errorf("classifySystemNote(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyLabelEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		action gitlab.LabelEventAction
+		want   string
+	}{
+		{"add", gitlab.AddLabelEventAction, "labeled"},
+		{"remove", gitlab.RemoveLabelEventAction, "unlabeled"},
+		{"unrecognized", gitlab.LabelEventAction("other"), "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyLabelEvent(tt.action); got != tt.want {
+				t.Errorf("classifyLabelEvent(%q) = %q, want %q", tt.action, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package gitlab
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/xanzy/go-gitlab"
+)
+
+// fetchPipelines walks the projects from repoNames and emits a gitlab_pipelines
+// point per pipeline returned by the GitLab API.
+func (h *Gitlab) fetchPipelines(acc telegraf.Accumulator) {
+	defer h.wg.Done()
+
+	var since *time.Time
+	if h.SincePipelines > 0 {
+		t := time.Now().Add(-h.SincePipelines)
+		since = &t
+	}
+
+	for _, rep := range h.repoNames() {
+		if h.ctx.Err() != nil {
+			return
+		}
+
+		key, ok := h.projectIDs[rep]
+		if !ok {
+			acc.AddError(fmt.Errorf("value does not exist in map"))
+			continue
+		}
+
+		opts := &gitlab.ListProjectPipelinesOptions{
+			ListOptions: gitlab.ListOptions{PerPage: 100, Page: 0},
+		}
+
+		statuses := h.PipelineStatuses
+		if len(statuses) == 0 {
+			statuses = []string{""}
+		}
+
+		for _, status := range statuses {
+			if h.ctx.Err() != nil {
+				return
+			}
+			if status != "" {
+				opts.Status = gitlab.BuildState(gitlab.BuildStateValue(status))
+			}
+			h.fetchPipelinesPage(acc, rep, key, opts, since)
+		}
+	}
+}
+
+func (h *Gitlab) fetchPipelinesPage(acc telegraf.Accumulator, rep string, key int, opts *gitlab.ListProjectPipelinesOptions, since *time.Time) {
+	pp := 100
+	page := 0
+	for {
+		opts.Page = page
+		opts.PerPage = pp
+
+		pipelines, rs, err := h.client.Pipelines.ListProjectPipelines(key, opts)
+		if err != nil {
+			if isTerminalAuthError(err) {
+				acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+				h.cancel()
+				return
+			}
+			acc.AddError(fmt.Errorf("unable to list pipelines for %s: %w", rep, err))
+			return
+		}
+
+		for _, pipeline := range pipelines {
+			full, _, err := h.client.Pipelines.GetPipeline(key, pipeline.ID)
+			if err != nil {
+				if isTerminalAuthError(err) {
+					acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+					h.cancel()
+					return
+				}
+				acc.AddError(fmt.Errorf("unable to get pipeline %d for %s: %w", pipeline.ID, rep, err))
+				continue
+			}
+
+			if since != nil && full.CreatedAt != nil && full.CreatedAt.Before(*since) {
+				continue
+			}
+
+			tmpFields := map[string]interface{}{
+				"duration":        full.Duration,
+				"queued_duration": full.QueuedDuration,
+				"coverage":        full.Coverage,
+				"created_at":      full.CreatedAt,
+				"started_at":      full.StartedAt,
+				"finished_at":     full.FinishedAt,
+			}
+
+			tmpTags := map[string]string{
+				"project": rep,
+				"status":  full.Status,
+				"ref":     full.Ref,
+				"sha":     full.SHA,
+			}
+
+			acc.AddFields("gitlab_pipelines", tmpFields, tmpTags, *full.CreatedAt)
+		}
+
+		rs.Body.Close()
+		page++
+		if len(pipelines) < pp {
+			break
+		}
+	}
+}
+
+// fetchJobs walks the projects from repoNames and emits a gitlab_jobs point per job,
+// mirroring the pagination and error handling of fetchPipelines. It runs
+// independently of fetchPipelines/CollectPipelines.
+func (h *Gitlab) fetchJobs(acc telegraf.Accumulator) {
+	defer h.wg.Done()
+
+	for _, rep := range h.repoNames() {
+		key, ok := h.projectIDs[rep]
+		if !ok {
+			acc.AddError(fmt.Errorf("value does not exist in map"))
+			continue
+		}
+
+		pp := 100
+		page := 0
+		for {
+			jobs, rs, err := h.client.Jobs.ListProjectJobs(key, &gitlab.ListJobsOptions{
+				ListOptions: gitlab.ListOptions{PerPage: pp, Page: page},
+			})
+			if err != nil {
+				if isTerminalAuthError(err) {
+					acc.AddError(fmt.Errorf("gitlab token is invalid or revoked, stopping: %w", err))
+					h.cancel()
+					return
+				}
+				acc.AddError(fmt.Errorf("unable to list jobs for %s: %w", rep, err))
+				break
+			}
+
+			for _, job := range jobs {
+				runnerID := 0
+				if job.Runner != nil {
+					runnerID = job.Runner.ID
+				}
+
+				tmpFields := map[string]interface{}{
+					"duration": job.Duration,
+					"retried":  job.Retry,
+				}
+
+				tmpTags := map[string]string{
+					"project":   rep,
+					"runner_id": fmt.Sprintf("%d", runnerID),
+					"stage":     job.Stage,
+					"name":      job.Name,
+					"status":    job.Status,
+				}
+
+				acc.AddFields("gitlab_jobs", tmpFields, tmpTags, *job.CreatedAt)
+			}
+
+			rs.Body.Close()
+			page++
+			if len(jobs) < pp {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package gitlab
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	defaultMaxRetries            = 5
+	defaultMaxConcurrentRequests = 10
+	defaultRateLimitFloor        = 5
+)
+
+// newTransport wraps base so that fetchCommits and fetchMergeRequests, which
+// page through the API back to back, don't get the token temporarily
+// banned on busy instances. It honors GitLab's RateLimit-Remaining/
+// RateLimit-Reset headers, retries 429/5xx responses with exponential
+// backoff and jitter, and bounds in-flight requests.
+func (h *Gitlab) newTransport(base http.RoundTripper, acc telegraf.Accumulator) http.RoundTripper {
+	maxRetries := h.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	maxConcurrent := h.MaxConcurrentRequests
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultMaxConcurrentRequests
+	}
+	floor := h.RateLimitFloor
+	if floor <= 0 {
+		floor = defaultRateLimitFloor
+	}
+
+	return &rateLimitedRoundTripper{
+		base:       base,
+		acc:        acc,
+		sem:        make(chan struct{}, maxConcurrent),
+		maxRetries: maxRetries,
+		floor:      floor,
+	}
+}
+
+type rateLimitedRoundTripper struct {
+	base       http.RoundTripper
+	acc        telegraf.Accumulator
+	sem        chan struct{}
+	maxRetries int
+	floor      int
+}
+
+func (t *rateLimitedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointName(req.URL.Path)
+	var retries, rateLimited int
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt <= t.maxRetries; attempt++ {
+		t.sem <- struct{}{}
+		resp, err = t.base.RoundTrip(req)
+		<-t.sem
+
+		if err == nil {
+			if remaining, reset, ok := rateLimitHeaders(resp); ok && remaining <= t.floor {
+				rateLimited++
+				sleepUntil(reset)
+			}
+
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError {
+				break
+			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				rateLimited++
+			}
+		}
+
+		if attempt == t.maxRetries {
+			break
+		}
+		retries++
+		time.Sleep(backoff(attempt))
+	}
+
+	if t.acc != nil && (retries > 0 || rateLimited > 0) {
+		t.acc.AddFields("gitlab_internal",
+			map[string]interface{}{
+				"retries":      retries,
+				"rate_limited": rateLimited,
+			},
+			map[string]string{"endpoint": endpoint},
+		)
+	}
+
+	return resp, err
+}
+
+// endpointName extracts a short, stable tag value (e.g. "commits",
+// "merge_requests") from a GitLab API request path for use in internal
+// metrics.
+func endpointName(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// A subresource is more specific than the projects/groups segment in
+	// front of it, so look for one first.
+	for i, p := range parts {
+		switch p {
+		case "repository":
+			if i+1 < len(parts) {
+				return parts[i+1]
+			}
+			return "unknown"
+		case "merge_requests", "pipelines", "jobs", "commits":
+			return p
+		}
+	}
+	for _, p := range parts {
+		switch p {
+		case "projects", "groups":
+			return p
+		}
+	}
+	return "unknown"
+}
+
+func rateLimitHeaders(resp *http.Response) (remaining int, reset time.Time, ok bool) {
+	remStr := resp.Header.Get("RateLimit-Remaining")
+	resetStr := resp.Header.Get("RateLimit-Reset")
+	if remStr == "" || resetStr == "" {
+		return 0, time.Time{}, false
+	}
+
+	remaining, err := strconv.Atoi(remStr)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+
+	resetUnix, err := strconv.ParseInt(resetStr, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+
+	return remaining, time.Unix(resetUnix, 0), true
+}
+
+func sleepUntil(reset time.Time) {
+	d := time.Until(reset)
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// backoff returns an exponential backoff duration with jitter for the given
+// (zero-indexed) retry attempt.
+func backoff(attempt int) time.Duration {
+	base := time.Duration(1<<uint(attempt)) * time.Second
+	jitter := time.Duration(rand.Int63n(int64(time.Second)))
+	return base + jitter
+}
@@ -0,0 +1,98 @@
+package gitlab
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEndpointName(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"merge requests", "/api/v4/projects/1/merge_requests", "merge_requests"},
+		{"pipelines", "/api/v4/projects/1/pipelines", "pipelines"},
+		{"jobs", "/api/v4/projects/1/jobs", "jobs"},
+		{"projects", "/api/v4/projects", "projects"},
+		{"groups", "/api/v4/groups/1/projects", "groups"},
+		{"commits", "/api/v4/projects/1/repository/commits", "commits"},
+		{"repository subresource", "/api/v4/projects/1/repository/branches", "branches"},
+		{"repository with nothing after it", "/api/v4/projects/1/repository", "unknown"},
+		{"unrecognized path", "/api/v4/users", "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := endpointName(tt.path); got != tt.want {
+				t.Errorf("endpointName(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		remaining   string
+		reset       string
+		wantOK      bool
+		wantRemain  int
+		wantResetAt int64
+	}{
+		{"both headers present", "3", "1700000000", true, 3, 1700000000},
+		{"missing remaining", "", "1700000000", false, 0, 0},
+		{"missing reset", "3", "", false, 0, 0},
+		{"non-numeric remaining", "nope", "1700000000", false, 0, 0},
+		{"non-numeric reset", "3", "nope", false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.remaining != "" {
+				resp.Header.Set("RateLimit-Remaining", tt.remaining)
+			}
+			if tt.reset != "" {
+				resp.Header.Set("RateLimit-Reset", tt.reset)
+			}
+
+			remaining, reset, ok := rateLimitHeaders(resp)
+			if ok != tt.wantOK {
+				t.Fatalf("rateLimitHeaders() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if remaining != tt.wantRemain {
+				t.Errorf("rateLimitHeaders() remaining = %d, want %d", remaining, tt.wantRemain)
+			}
+			if !reset.Equal(time.Unix(tt.wantResetAt, 0)) {
+				t.Errorf("rateLimitHeaders() reset = %v, want %v", reset, time.Unix(tt.wantResetAt, 0))
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"attempt 0", 0, 1 * time.Second, 2 * time.Second},
+		{"attempt 1", 1, 2 * time.Second, 3 * time.Second},
+		{"attempt 2", 2, 4 * time.Second, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := backoff(tt.attempt)
+			if d < tt.min || d >= tt.max {
+				t.Errorf("backoff(%d) = %v, want in [%v, %v)", tt.attempt, d, tt.min, tt.max)
+			}
+		})
+	}
+}
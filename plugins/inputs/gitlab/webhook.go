@@ -0,0 +1,197 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/xanzy/go-gitlab"
+)
+
+// listen starts an HTTP server that accepts GitLab webhook deliveries and
+// emits them as metrics, using each event's own timestamp. It runs alongside
+// the poller goroutines. Events are tagged with the project named in the
+// payload; h.projects is not consulted, so events for projects the poller
+// does not know about are emitted too.
+func (h *Gitlab) listen(acc telegraf.Accumulator) {
+	defer h.wg.Done()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", h.handleWebhook(acc))
+
+	h.server = &http.Server{
+		Addr:    h.ListenAddress,
+		Handler: mux,
+	}
+
+	go func() {
+		<-h.ctx.Done()
+		h.server.Shutdown(context.Background())
+	}()
+
+	if err := h.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		acc.AddError(fmt.Errorf("gitlab webhook server error: %w", err))
+	}
+}
+
+func (h *Gitlab) handleWebhook(acc telegraf.Accumulator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Start refuses to launch the webhook receiver at all when
+		// WebhookSecret is empty, so this is always a real comparison.
+		if r.Header.Get("X-Gitlab-Token") != h.WebhookSecret {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "unable to read body", http.StatusBadRequest)
+			return
+		}
+
+		eventType := gitlab.WebhookEventType(r)
+		event, err := gitlab.ParseWebhook(eventType, body)
+		if err != nil {
+			acc.AddError(fmt.Errorf("unable to parse gitlab webhook event %q: %w", eventType, err))
+			http.Error(w, "unable to parse event", http.StatusBadRequest)
+			return
+		}
+
+		switch e := event.(type) {
+		case *gitlab.PushEvent:
+			h.handlePushEvent(acc, e)
+		case *gitlab.MergeEvent:
+			h.handleMergeEvent(acc, e)
+		case *gitlab.PipelineEvent:
+			h.handlePipelineEvent(acc, e)
+		case *gitlab.JobEvent:
+			h.handleJobEvent(acc, e)
+		case *gitlab.NoteEvent:
+			h.handleNoteEvent(acc, e)
+		default:
+			acc.AddError(fmt.Errorf("unsupported gitlab webhook event %q", eventType))
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (h *Gitlab) handlePushEvent(acc telegraf.Accumulator, e *gitlab.PushEvent) {
+	ts := time.Now()
+	if n := len(e.Commits); n > 0 && e.Commits[n-1].Timestamp != nil {
+		ts = *e.Commits[n-1].Timestamp
+	}
+
+	tmpFields := map[string]interface{}{
+		"checkout_sha":        e.CheckoutSHA,
+		"commits":             len(e.Commits),
+		"total_commits_count": e.TotalCommitsCount,
+	}
+
+	tmpTags := map[string]string{
+		"project": e.Project.PathWithNamespace,
+		"ref":     e.Ref,
+		"user":    e.UserUsername,
+	}
+
+	acc.AddFields("gitlab_push", tmpFields, tmpTags, ts)
+}
+
+func (h *Gitlab) handleMergeEvent(acc telegraf.Accumulator, e *gitlab.MergeEvent) {
+	attrs := e.ObjectAttributes
+
+	ts := time.Now()
+	if attrs.UpdatedAt != "" {
+		if parsed, err := time.Parse("2006-01-02 15:04:05 MST", attrs.UpdatedAt); err == nil {
+			ts = parsed
+		}
+	}
+
+	tmpFields := map[string]interface{}{
+		"iid":         attrs.IID,
+		"upvotes":     attrs.Upvotes,
+		"downvotes":   attrs.Downvotes,
+		"notes_count": attrs.UserNotesCount,
+	}
+
+	tmpTags := map[string]string{
+		"project": e.Project.PathWithNamespace,
+		"state":   attrs.State,
+		"action":  attrs.Action,
+	}
+
+	acc.AddFields("gitlab_merge_request_event", tmpFields, tmpTags, ts)
+}
+
+func (h *Gitlab) handlePipelineEvent(acc telegraf.Accumulator, e *gitlab.PipelineEvent) {
+	attrs := e.ObjectAttributes
+
+	ts := time.Now()
+	if attrs.CreatedAt != "" {
+		if parsed, err := time.Parse("2006-01-02 15:04:05 MST", attrs.CreatedAt); err == nil {
+			ts = parsed
+		}
+	}
+
+	tmpFields := map[string]interface{}{
+		"id":       attrs.ID,
+		"duration": attrs.Duration,
+	}
+
+	tmpTags := map[string]string{
+		"project": e.Project.PathWithNamespace,
+		"status":  attrs.Status,
+		"ref":     attrs.Ref,
+	}
+
+	acc.AddFields("gitlab_pipeline_event", tmpFields, tmpTags, ts)
+}
+
+func (h *Gitlab) handleJobEvent(acc telegraf.Accumulator, e *gitlab.JobEvent) {
+	ts := time.Now()
+	if e.BuildStartedAt != "" {
+		if parsed, err := time.Parse("2006-01-02 15:04:05 MST", e.BuildStartedAt); err == nil {
+			ts = parsed
+		}
+	}
+
+	tmpFields := map[string]interface{}{
+		"build_id":       e.BuildID,
+		"build_duration": e.BuildDuration,
+	}
+
+	tmpTags := map[string]string{
+		"project": e.ProjectName,
+		"status":  e.BuildStatus,
+		"stage":   e.BuildStage,
+	}
+
+	acc.AddFields("gitlab_job_event", tmpFields, tmpTags, ts)
+}
+
+func (h *Gitlab) handleNoteEvent(acc telegraf.Accumulator, e *gitlab.NoteEvent) {
+	attrs := e.ObjectAttributes
+
+	ts := time.Now()
+	if attrs.CreatedAt != "" {
+		if parsed, err := time.Parse("2006-01-02 15:04:05 MST", attrs.CreatedAt); err == nil {
+			ts = parsed
+		}
+	}
+
+	tmpFields := map[string]interface{}{
+		"note_id": attrs.ID,
+		"note":    attrs.Note,
+	}
+
+	tmpTags := map[string]string{
+		"project":  e.Project.PathWithNamespace,
+		"noteable": attrs.NoteableType,
+		"author":   e.User.Username,
+	}
+
+	acc.AddFields("gitlab_note_event", tmpFields, tmpTags, ts)
+}